@@ -13,6 +13,13 @@ type Device struct {
 	URL      string `yaml:"url"`
 	Username string `yaml:"username"`
 	Password string `yaml:"password"`
+
+	// TLS settings for scraping HTTPS devices. Supla devices on LAN
+	// commonly present self-signed certificates.
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	CACertFile         string `yaml:"ca_cert_file"`
+	ClientCertFile     string `yaml:"client_cert_file"`
+	ClientKeyFile      string `yaml:"client_key_file"`
 }
 
 type GlobalConfig struct {
@@ -23,9 +30,31 @@ type GlobalConfig struct {
 	Workers  int    `yaml:"workers"`
 }
 
+// DiscoveryConfig selects a dynamic target discovery provider, so Devices
+// does not have to be the only source of scrape targets.
+type DiscoveryConfig struct {
+	// Provider is "file_sd", "http_sd", or empty to disable discovery.
+	Provider string `yaml:"provider"`
+	// RefreshInterval is how often a polling provider (http_sd) re-fetches
+	// its target list, in seconds. file_sd ignores this and instead
+	// reacts to filesystem change events.
+	RefreshInterval int          `yaml:"refresh_interval"`
+	FileSD          FileSDConfig `yaml:"file_sd"`
+	HTTPSD          HTTPSDConfig `yaml:"http_sd"`
+}
+
+type FileSDConfig struct {
+	Path string `yaml:"path"`
+}
+
+type HTTPSDConfig struct {
+	URL string `yaml:"url"`
+}
+
 type Config struct {
-	Global  GlobalConfig `yaml:"global"`
-	Devices []Device     `yaml:"devices"`
+	Global    GlobalConfig    `yaml:"global"`
+	Devices   []Device        `yaml:"devices"`
+	Discovery DiscoveryConfig `yaml:"discovery"`
 }
 
 // Default values for global config
@@ -85,8 +114,26 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("global.workers must be between 1 and 100")
 	}
 
-	// Validate devices
-	if len(cfg.Devices) == 0 {
+	// Validate discovery
+	switch cfg.Discovery.Provider {
+	case "":
+		// discovery disabled, devices must come from the static list below
+	case "file_sd":
+		if cfg.Discovery.FileSD.Path == "" {
+			return fmt.Errorf("discovery.file_sd.path is required")
+		}
+	case "http_sd":
+		if cfg.Discovery.HTTPSD.URL == "" {
+			return fmt.Errorf("discovery.http_sd.url is required")
+		}
+	default:
+		return fmt.Errorf("discovery.provider must be one of: file_sd, http_sd")
+	}
+
+	// Validate devices. A discovery provider may supply targets instead of
+	// (or in addition to) the static list, so only require it when
+	// discovery is disabled.
+	if len(cfg.Devices) == 0 && cfg.Discovery.Provider == "" {
 		return fmt.Errorf("no devices configured")
 	}
 
@@ -106,6 +153,24 @@ func validate(cfg *Config) error {
 		if dev.Password == "" {
 			return fmt.Errorf("device %d: password is required", i)
 		}
+
+		// Check TLS settings
+		if dev.CACertFile != "" {
+			if _, err := os.Stat(dev.CACertFile); err != nil {
+				return fmt.Errorf("device %d: ca_cert_file not accessible: %w", i, err)
+			}
+		}
+		if (dev.ClientCertFile == "") != (dev.ClientKeyFile == "") {
+			return fmt.Errorf("device %d: client_cert_file and client_key_file must both be set or both be empty", i)
+		}
+		if dev.ClientCertFile != "" {
+			if _, err := os.Stat(dev.ClientCertFile); err != nil {
+				return fmt.Errorf("device %d: client_cert_file not accessible: %w", i, err)
+			}
+			if _, err := os.Stat(dev.ClientKeyFile); err != nil {
+				return fmt.Errorf("device %d: client_key_file not accessible: %w", i, err)
+			}
+		}
 	}
 
 	return nil
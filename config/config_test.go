@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, yaml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigValidDevice(t *testing.T) {
+	path := writeConfig(t, `
+devices:
+  - url: http://device1/status
+    username: u
+    password: p
+`)
+	if _, err := LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+}
+
+func TestLoadConfigDiscoveryUnknownProvider(t *testing.T) {
+	path := writeConfig(t, `
+devices:
+  - url: http://device1/status
+    username: u
+    password: p
+discovery:
+  provider: made_up_sd
+`)
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected an error for an unknown discovery.provider")
+	}
+}
+
+func TestLoadConfigDiscoveryFileSDRequiresPath(t *testing.T) {
+	path := writeConfig(t, `
+discovery:
+  provider: file_sd
+`)
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected an error when discovery.file_sd.path is missing")
+	}
+}
+
+func TestLoadConfigDiscoveryHTTPSDRequiresURL(t *testing.T) {
+	path := writeConfig(t, `
+discovery:
+  provider: http_sd
+`)
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected an error when discovery.http_sd.url is missing")
+	}
+}
+
+func TestLoadConfigDiscoveryAloneSatisfiesNoDevices(t *testing.T) {
+	path := writeConfig(t, `
+discovery:
+  provider: file_sd
+  file_sd:
+    path: /tmp/targets.json
+`)
+	if _, err := LoadConfig(path); err != nil {
+		t.Fatalf("expected discovery alone to satisfy the device requirement, got: %v", err)
+	}
+}
+
+func TestLoadConfigDeviceCACertFileMustExist(t *testing.T) {
+	path := writeConfig(t, `
+devices:
+  - url: http://device1/status
+    username: u
+    password: p
+    ca_cert_file: /nonexistent/ca.pem
+`)
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected an error for an unreadable ca_cert_file")
+	}
+}
+
+func TestLoadConfigDeviceClientCertRequiresBothFiles(t *testing.T) {
+	path := writeConfig(t, `
+devices:
+  - url: http://device1/status
+    username: u
+    password: p
+    client_cert_file: /tmp/cert.pem
+`)
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected an error when client_key_file is missing alongside client_cert_file")
+	}
+}
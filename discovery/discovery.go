@@ -0,0 +1,126 @@
+// Package discovery provides dynamic target discovery for the exporter, so
+// cfg.Devices is no longer the only source of scrape targets. It mirrors the
+// provider model used by Prometheus's own service discovery (file_sd_config,
+// http_sd_config).
+//
+// file_sd/http_sd target entries accept a "labels" map, matching
+// Prometheus's own SD shape, but this exporter has no per-target label
+// passthrough: Collector's GaugeVecs declare a fixed label schema at
+// registration time, so there is nowhere for arbitrary discovered labels
+// to land on a metric today. Target.Labels is parsed and kept for
+// shape-compatibility with file_sd/http_sd producers but is not currently
+// applied anywhere.
+package discovery
+
+import (
+	"fmt"
+	"time"
+
+	"supla_exporter/config"
+)
+
+// Target is a single discovered device, matching the shape Prometheus's own
+// SD mechanisms use: a URL plus optional credentials and labels. It also
+// carries the same per-device TLS settings as config.Device, since
+// discovered devices are a first-class target source and not just another
+// way to list config.yaml entries.
+type Target struct {
+	URL      string            `json:"url" yaml:"url"`
+	Username string            `json:"username" yaml:"username"`
+	Password string            `json:"password" yaml:"password"`
+	Labels   map[string]string `json:"labels" yaml:"labels"`
+
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+	CACertFile         string `json:"ca_cert_file" yaml:"ca_cert_file"`
+	ClientCertFile     string `json:"client_cert_file" yaml:"client_cert_file"`
+	ClientKeyFile      string `json:"client_key_file" yaml:"client_key_file"`
+}
+
+// Provider is a source of dynamically discovered targets.
+type Provider interface {
+	// Name identifies the provider for logging.
+	Name() string
+	// Targets returns the current set of discovered targets.
+	Targets() []Target
+	// Stop releases any resources held by the provider (watchers, tickers).
+	Stop()
+}
+
+// Manager wraps a single discovery Provider and exposes its targets as
+// config.Device values, so the rest of the exporter does not need to know
+// where the device list came from.
+type Manager struct {
+	provider Provider
+}
+
+// NewManager builds a Manager from a discovery config section. An empty
+// cfg.Provider yields a nil Manager, meaning the caller should rely solely
+// on the static cfg.Devices list.
+func NewManager(cfg config.DiscoveryConfig) (*Manager, error) {
+	refresh := time.Duration(cfg.RefreshInterval) * time.Second
+	if refresh <= 0 {
+		refresh = 30 * time.Second
+	}
+
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+
+	case "file_sd":
+		if cfg.FileSD.Path == "" {
+			return nil, fmt.Errorf("discovery.file_sd.path is required")
+		}
+		provider, err := NewFileSDProvider(cfg.FileSD.Path)
+		if err != nil {
+			return nil, fmt.Errorf("starting file_sd provider: %w", err)
+		}
+		return &Manager{provider: provider}, nil
+
+	case "http_sd":
+		if cfg.HTTPSD.URL == "" {
+			return nil, fmt.Errorf("discovery.http_sd.url is required")
+		}
+		provider, err := NewHTTPSDProvider(cfg.HTTPSD.URL, refresh)
+		if err != nil {
+			return nil, fmt.Errorf("starting http_sd provider: %w", err)
+		}
+		return &Manager{provider: provider}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown discovery provider: %s", cfg.Provider)
+	}
+}
+
+// Devices returns the current set of discovered devices as config.Device
+// values, ready to be handed to parser.Pool.Run. Calling Devices on a nil
+// Manager is valid and returns nil.
+func (m *Manager) Devices() []config.Device {
+	if m == nil {
+		return nil
+	}
+
+	targets := m.provider.Targets()
+	devices := make([]config.Device, 0, len(targets))
+	for _, t := range targets {
+		devices = append(devices, config.Device{
+			URL:      t.URL,
+			Username: t.Username,
+			Password: t.Password,
+
+			InsecureSkipVerify: t.InsecureSkipVerify,
+			CACertFile:         t.CACertFile,
+			ClientCertFile:     t.ClientCertFile,
+			ClientKeyFile:      t.ClientKeyFile,
+		})
+	}
+	return devices
+}
+
+// Stop releases resources held by the underlying provider. Calling Stop on
+// a nil Manager is valid and does nothing.
+func (m *Manager) Stop() {
+	if m == nil {
+		return
+	}
+	m.provider.Stop()
+}
@@ -0,0 +1,121 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// FileSDProvider watches a JSON or YAML file containing a list of targets
+// and hot-reloads it on change, mirroring Prometheus's file_sd_config.
+type FileSDProvider struct {
+	path string
+
+	mu      sync.RWMutex
+	targets []Target
+
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// NewFileSDProvider loads path once and starts watching it for changes.
+func NewFileSDProvider(path string) (*FileSDProvider, error) {
+	p := &FileSDProvider{path: path, done: make(chan struct{})}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating file watcher: %w", err)
+	}
+	// Watch the containing directory rather than the file itself, since
+	// editors commonly replace the file (rename+create) rather than
+	// writing it in place.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("watching %s: %w", path, err)
+	}
+	p.watcher = watcher
+
+	go p.watch()
+	return p, nil
+}
+
+// Name implements Provider.
+func (p *FileSDProvider) Name() string { return "file_sd" }
+
+// Targets implements Provider.
+func (p *FileSDProvider) Targets() []Target {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.targets
+}
+
+// Stop implements Provider.
+func (p *FileSDProvider) Stop() {
+	close(p.done)
+	if p.watcher != nil {
+		p.watcher.Close()
+	}
+}
+
+func (p *FileSDProvider) watch() {
+	for {
+		select {
+		case event, ok := <-p.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(p.path) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if err := p.reload(); err != nil {
+				slog.Error("Error reloading file_sd targets", "path", p.path, "error", err)
+			}
+
+		case err, ok := <-p.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("file_sd watcher error", "path", p.path, "error", err)
+
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *FileSDProvider) reload() error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("reading file_sd file: %w", err)
+	}
+
+	var targets []Target
+	if strings.HasSuffix(p.path, ".json") {
+		err = json.Unmarshal(data, &targets)
+	} else {
+		err = yaml.Unmarshal(data, &targets)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing file_sd file: %w", err)
+	}
+
+	p.mu.Lock()
+	p.targets = targets
+	p.mu.Unlock()
+
+	slog.Info("file_sd targets reloaded", "path", p.path, "count", len(targets))
+	return nil
+}
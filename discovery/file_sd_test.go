@@ -0,0 +1,60 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFileSDProviderLoadsAndReloads verifies that FileSDProvider loads the
+// initial target list and picks up changes written to the file afterwards,
+// mirroring Prometheus's file_sd_config hot-reload behavior.
+func TestFileSDProviderLoadsAndReloads(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "targets.json")
+
+	initial := `[{"url":"http://device1/status","username":"u","password":"p"}]`
+	if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+		t.Fatalf("writing initial targets file: %v", err)
+	}
+
+	p, err := NewFileSDProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileSDProvider: %v", err)
+	}
+	defer p.Stop()
+
+	targets := p.Targets()
+	if len(targets) != 1 || targets[0].URL != "http://device1/status" {
+		t.Fatalf("unexpected initial targets: %+v", targets)
+	}
+
+	updated := `[{"url":"http://device1/status","username":"u","password":"p"},{"url":"http://device2/status","username":"u2","password":"p2"}]`
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		t.Fatalf("writing updated targets file: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if len(p.Targets()) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	targets = p.Targets()
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 targets after reload, got %d: %+v", len(targets), targets)
+	}
+}
+
+// TestManagerDevicesNil verifies that a nil Manager (no discovery provider
+// configured) returns no devices instead of panicking.
+func TestManagerDevicesNil(t *testing.T) {
+	var m *Manager
+	if devices := m.Devices(); devices != nil {
+		t.Fatalf("expected nil devices from nil Manager, got %+v", devices)
+	}
+	m.Stop()
+}
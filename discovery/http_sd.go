@@ -0,0 +1,94 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPSDProvider periodically polls a URL returning a JSON list of targets,
+// mirroring Prometheus's http_sd_config.
+type HTTPSDProvider struct {
+	url      string
+	interval time.Duration
+	client   *http.Client
+
+	mu      sync.RWMutex
+	targets []Target
+
+	done chan struct{}
+}
+
+// NewHTTPSDProvider fetches url once and starts polling it every interval.
+func NewHTTPSDProvider(url string, interval time.Duration) (*HTTPSDProvider, error) {
+	p := &HTTPSDProvider{
+		url:      url,
+		interval: interval,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		done:     make(chan struct{}),
+	}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+
+	go p.run()
+	return p, nil
+}
+
+// Name implements Provider.
+func (p *HTTPSDProvider) Name() string { return "http_sd" }
+
+// Targets implements Provider.
+func (p *HTTPSDProvider) Targets() []Target {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.targets
+}
+
+// Stop implements Provider.
+func (p *HTTPSDProvider) Stop() {
+	close(p.done)
+}
+
+func (p *HTTPSDProvider) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.reload(); err != nil {
+				slog.Error("Error reloading http_sd targets", "url", p.url, "error", err)
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *HTTPSDProvider) reload() error {
+	resp, err := p.client.Get(p.url)
+	if err != nil {
+		return fmt.Errorf("fetching http_sd targets: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http_sd endpoint returned status %d", resp.StatusCode)
+	}
+
+	var targets []Target
+	if err := json.NewDecoder(resp.Body).Decode(&targets); err != nil {
+		return fmt.Errorf("decoding http_sd response: %w", err)
+	}
+
+	p.mu.Lock()
+	p.targets = targets
+	p.mu.Unlock()
+
+	slog.Info("http_sd targets reloaded", "url", p.url, "count", len(targets))
+	return nil
+}
@@ -0,0 +1,79 @@
+package discovery
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestHTTPSDProviderLoadsAndReloads verifies that HTTPSDProvider loads the
+// initial target list from its URL and picks up changes on the next poll,
+// mirroring Prometheus's http_sd_config behavior.
+func TestHTTPSDProviderLoadsAndReloads(t *testing.T) {
+	var mu sync.Mutex
+	body := `[{"url":"http://device1/status","username":"u","password":"p"}]`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	p, err := NewHTTPSDProvider(server.URL, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewHTTPSDProvider: %v", err)
+	}
+	defer p.Stop()
+
+	targets := p.Targets()
+	if len(targets) != 1 || targets[0].URL != "http://device1/status" {
+		t.Fatalf("unexpected initial targets: %+v", targets)
+	}
+
+	mu.Lock()
+	body = `[{"url":"http://device1/status","username":"u","password":"p"},{"url":"http://device2/status","username":"u2","password":"p2"}]`
+	mu.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if len(p.Targets()) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	targets = p.Targets()
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 targets after reload, got %d: %+v", len(targets), targets)
+	}
+}
+
+// TestHTTPSDProviderNon200 verifies that a non-200 response fails
+// NewHTTPSDProvider instead of silently starting with an empty target list.
+func TestHTTPSDProviderNon200(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if _, err := NewHTTPSDProvider(server.URL, time.Second); err == nil {
+		t.Fatal("expected error from non-200 http_sd endpoint, got nil")
+	}
+}
+
+// TestHTTPSDProviderBadJSON verifies that an invalid JSON body fails
+// NewHTTPSDProvider with a decode error.
+func TestHTTPSDProviderBadJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "not json")
+	}))
+	defer server.Close()
+
+	if _, err := NewHTTPSDProvider(server.URL, time.Second); err == nil {
+		t.Fatal("expected error from malformed http_sd response, got nil")
+	}
+}
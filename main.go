@@ -1,51 +1,122 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"runtime"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
+
 	"supla_exporter/config"
+	"supla_exporter/discovery"
 	"supla_exporter/metrics"
 	"supla_exporter/parser"
-	"time"
+	"supla_exporter/webconfig"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func updateMetrics(devices []config.Device, cfg *config.Config) {
-	// Reset device count before updating
-	parser.GetAndResetDeviceCount() // This resets the count to 0
+// version and commit are set at build time via -ldflags, e.g.:
+//
+//	go build -ldflags "-X main.version=1.2.3 -X main.commit=$(git rev-parse --short HEAD)"
+var (
+	version = "dev"
+	commit  = "none"
+)
+
+// lastDeviceURLs tracks the device set from the previous tick so removed
+// devices (dropped from config.yaml or a discovery provider) have their
+// stale series deleted instead of lingering in /metrics forever.
+var lastDeviceURLs = map[string]struct{}{}
+
+func updateMetrics(ctx context.Context, cfg *config.Config, discoveryMgr *discovery.Manager, collector *metrics.Collector, pmetrics *parser.Metrics, pool *parser.Pool) {
+	devices := append(append([]config.Device{}, cfg.Devices...), discoveryMgr.Devices()...)
+
+	currentURLs := make(map[string]struct{}, len(devices))
+	for _, d := range devices {
+		currentURLs[d.URL] = struct{}{}
+	}
+	for url := range lastDeviceURLs {
+		if _, ok := currentURLs[url]; !ok {
+			collector.DeleteDevice(url)
+			pmetrics.DeleteDevice(url)
+		}
+	}
+	lastDeviceURLs = currentURLs
 
 	// Use worker pool instead of sequential processing
-	numWorkers := cfg.Global.Workers
-	results := parser.FetchAndParseWithPool(devices, numWorkers)
+	results := pool.Run(ctx, devices)
 
 	// Process results and update metrics
 	for _, info := range results {
 		if info != nil {
-			metrics.UpdateMetrics(info)
+			collector.UpdateMetrics(info)
 		}
 	}
-	// for _, device := range devices {
-	// 	info, err := parser.FetchAndParse(device)
-	// 	if err != nil {
-	// 		slog.Error("Error fetching data from device",
-	// 			"url", device.URL,
-	// 			"error", err,
-	// 		)
-	// 		continue
-	// 	}
-	// 	metrics.UpdateMetrics(info)
-	// }
 
 	// Get and log the device count
-	deviceCount := parser.GetAndResetDeviceCount()
+	deviceCount := pool.GetAndResetDeviceCount()
+	collector.SetDeviceCount(deviceCount)
 	slog.Debug("Metrics update completed", "devices_processed", deviceCount)
 }
 
+// probeHandler implements the Prometheus multi-target pattern: it scrapes a
+// single device named by the "target" query parameter on demand and returns
+// metrics scoped to just that device, letting Prometheus drive the scrape
+// interval via scrape_interval/relabeling instead of the background ticker.
+func probeHandler(cfg *config.Config, discoveryMgr *discovery.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		target := r.URL.Query().Get("target")
+		if target == "" {
+			http.Error(w, "target parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		device := lookupDevice(cfg, discoveryMgr, target)
+
+		// target is attacker-controlled: FetchAndParse's self-observability
+		// metrics must never be recorded on the exporter's served pmetrics,
+		// or anyone hitting /probe could inject unbounded-cardinality url
+		// label values into /metrics. Give this probe its own throwaway,
+		// unexposed registry for those instead; DeviceCollector separately
+		// reports the duration/success of just this one target on the
+		// registry actually served below.
+		discardMetrics := parser.NewMetrics(prometheus.NewRegistry())
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(metrics.NewDeviceCollector(device, discardMetrics))
+
+		promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	}
+}
+
+// lookupDevice returns the device matching target (so its credentials are
+// used), checking cfg.Devices first and then discoveryMgr, so a device
+// supplied only by file_sd/http_sd can still be probed correctly. Returns a
+// bare device with no credentials if target is present in neither.
+func lookupDevice(cfg *config.Config, discoveryMgr *discovery.Manager, target string) config.Device {
+	for _, d := range cfg.Devices {
+		if d.URL == target {
+			return d
+		}
+	}
+	for _, d := range discoveryMgr.Devices() {
+		if d.URL == target {
+			return d
+		}
+	}
+	return config.Device{URL: target}
+}
+
 func getLogLevel(level string) slog.Level {
 	switch strings.ToLower(level) {
 	case "debug":
@@ -64,6 +135,7 @@ func getLogLevel(level string) slog.Level {
 func main() {
 	// Parse command line flags
 	configFile := flag.String("config", "config/config.yaml", "path to config file")
+	webConfigFile := flag.String("web.config.file", "", "path to web config file for TLS and basic auth")
 	flag.Parse()
 
 	// Define config paths to try
@@ -108,28 +180,119 @@ func main() {
 	slog.Info("Log level set", "level", cfg.Global.LogLevel)
 	slog.Debug("This is a debug message to verify log level")
 
+	// Build an explicit registry rather than relying on the global
+	// promauto default, so a /probe scrape's own registry (see
+	// probeHandler) never collides with it.
+	reg := prometheus.NewRegistry()
+	collector := metrics.NewCollector(reg)
+	collector.SetBuildInfo(version, commit, runtime.Version())
+	pmetrics := parser.NewMetrics(reg)
+
+	pool := parser.NewPool(cfg.Global.Workers, pmetrics)
+
+	// Start the discovery provider (if configured) so updateMetrics can
+	// pull the current device set from it each tick instead of relying
+	// solely on the static cfg.Devices list.
+	discoveryMgr, err := discovery.NewManager(cfg.Discovery)
+	if err != nil {
+		slog.Error("Error starting discovery", "error", err)
+		os.Exit(1)
+	}
+	defer discoveryMgr.Stop()
+
+	// Load and validate the web config (TLS, basic auth) before the first
+	// real scrape, if one was given. A typo'd web-config should fail fast
+	// here rather than after every device has already been hit once by the
+	// initial updateMetrics below.
+	var webCfg *webconfig.Config
+	var tlsConfig *tls.Config
+	if *webConfigFile != "" {
+		webCfg, err = webconfig.Load(*webConfigFile)
+		if err != nil {
+			slog.Error("Error loading web config", "error", err)
+			os.Exit(1)
+		}
+		if webCfg.TLSServerConfig.CertFile != "" || webCfg.TLSServerConfig.KeyFile != "" {
+			tlsConfig, err = webCfg.TLSConfig()
+			if err != nil {
+				slog.Error("Error building TLS config", "error", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	// ctx is cancelled on SIGINT/SIGTERM, which also cancels the device
+	// requests of a scrape in progress; inFlight is drained before the
+	// server shuts down so that scrape has returned before exit.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var inFlight sync.WaitGroup
+
 	// Initial metrics update
-	updateMetrics(cfg.Devices, cfg)
+	inFlight.Add(1)
+	func() {
+		defer inFlight.Done()
+		updateMetrics(ctx, cfg, discoveryMgr, collector, pmetrics, pool)
+	}()
 
 	// Start periodic updates with configured interval
 	go func() {
 		ticker := time.NewTicker(time.Duration(cfg.Global.Interval) * time.Second)
-		for range ticker.C {
-			updateMetrics(cfg.Devices, cfg)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				inFlight.Add(1)
+				updateMetrics(ctx, cfg, discoveryMgr, collector, pmetrics, pool)
+				inFlight.Done()
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	mux.Handle("/probe", probeHandler(cfg, discoveryMgr))
+
+	var handler http.Handler = mux
+	if webCfg != nil {
+		handler = webCfg.BasicAuthMiddleware(handler)
+	}
+
 	// Start metrics server on configured port
 	addr := fmt.Sprintf(":%d", cfg.Global.Port)
-	slog.Info("Starting metrics server",
-		"address", addr,
-	)
-	http.Handle("/metrics", promhttp.Handler())
-	if err := http.ListenAndServe(addr, nil); err != nil {
-		slog.Error("Failed to start metrics server",
-			"address", addr,
-			"error", err,
-		)
-		os.Exit(1)
+	server := &http.Server{Addr: addr, Handler: handler}
+
+	useTLS := tlsConfig != nil
+	if useTLS {
+		server.TLSConfig = tlsConfig
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("Starting metrics server", "address", addr, "tls", useTLS)
+		if useTLS {
+			serveErr <- server.ListenAndServeTLS("", "")
+		} else {
+			serveErr <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err = <-serveErr:
+		if err != nil && err != http.ErrServerClosed {
+			slog.Error("Failed to start metrics server", "address", addr, "error", err)
+			os.Exit(1)
+		}
+	case <-ctx.Done():
+		slog.Info("Shutdown signal received, draining in-flight scrapes")
+		inFlight.Wait()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err = server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Error shutting down metrics server", "error", err)
+		}
 	}
 }
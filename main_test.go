@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"supla_exporter/config"
+)
+
+// TestProbeHandlerScrapesConfiguredDevice verifies that /probe?target=...
+// scrapes the matching configured device and serves its metrics.
+func TestProbeHandlerScrapesConfiguredDevice(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"dev1"}`))
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{Devices: []config.Device{{URL: srv.URL, Username: "u", Password: "p"}}}
+	handler := probeHandler(cfg, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/probe?target="+srv.URL, nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `supla_device_up{url="`+srv.URL+`"} 1`) {
+		t.Fatalf("expected supla_device_up=1 for %s in response, got:\n%s", srv.URL, rec.Body.String())
+	}
+}
+
+// TestProbeHandlerUnknownTargetDoesNotPanic verifies that probing an
+// unrecognized, malformed target (not matching any configured or
+// discovered device) neither panics nor returns a server error.
+func TestProbeHandlerUnknownTargetDoesNotPanic(t *testing.T) {
+	cfg := &config.Config{}
+	handler := probeHandler(cfg, nil)
+
+	target := "http://\x7f"
+	req := httptest.NewRequest(http.MethodGet, "/probe?target="+url.QueryEscape(target), nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 even for an unreachable/malformed target, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestProbeHandlerMissingTarget verifies the required "target" query
+// parameter is enforced.
+func TestProbeHandlerMissingTarget(t *testing.T) {
+	cfg := &config.Config{}
+	handler := probeHandler(cfg, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/probe", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for missing target, got %d", rec.Code)
+	}
+}
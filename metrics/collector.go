@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"supla_exporter/config"
+	"supla_exporter/parser"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DeviceCollector implements prometheus.Collector for a single, on-demand
+// device scrape. It is used by the /probe handler so that the scrape
+// interval and target set can be driven entirely by Prometheus (via
+// scrape_interval and relabeling) instead of the background ticker in
+// main.go, and so devices that are not present in config.yaml can still
+// be probed.
+type DeviceCollector struct {
+	device  config.Device
+	metrics *parser.Metrics
+
+	info     *prometheus.Desc
+	state    *prometheus.Desc
+	network  *prometheus.Desc
+	firmware *prometheus.Desc
+	memory   *prometheus.Desc
+	up       *prometheus.Desc
+	duration *prometheus.Desc
+	success  *prometheus.Desc
+}
+
+// NewDeviceCollector builds a collector that scrapes device when Collect is
+// called, recording the scrape on m.
+func NewDeviceCollector(device config.Device, m *parser.Metrics) *DeviceCollector {
+	return &DeviceCollector{
+		device:  device,
+		metrics: m,
+		info: prometheus.NewDesc(
+			"supla_device_info", "Base device information indicating device presence",
+			[]string{"url", "name"}, nil,
+		),
+		state: prometheus.NewDesc(
+			"supla_device_state", "Device operational state",
+			[]string{"url", "state"}, nil,
+		),
+		network: prometheus.NewDesc(
+			"supla_device_network", "Device network information",
+			[]string{"url", "mac"}, nil,
+		),
+		firmware: prometheus.NewDesc(
+			"supla_device_firmware", "Device firmware version",
+			[]string{"url", "firmware", "name"}, nil,
+		),
+		memory: prometheus.NewDesc(
+			"supla_device_memory_free_bytes", "Free memory in bytes",
+			[]string{"url", "name"}, nil,
+		),
+		up: prometheus.NewDesc(
+			"supla_device_up", "Device availability status (1=up, 0=down)",
+			[]string{"url"}, nil,
+		),
+		duration: prometheus.NewDesc(
+			"supla_scrape_duration_seconds", "Time taken to scrape the probed device",
+			[]string{"url"}, nil,
+		),
+		success: prometheus.NewDesc(
+			"supla_scrape_success", "Whether the probe of the device succeeded",
+			[]string{"url"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *DeviceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.info
+	ch <- c.state
+	ch <- c.network
+	ch <- c.firmware
+	ch <- c.memory
+	ch <- c.up
+	ch <- c.duration
+	ch <- c.success
+}
+
+// Collect implements prometheus.Collector. It performs the scrape inline,
+// so the duration of Collect is the duration of the probe itself.
+func (c *DeviceCollector) Collect(ch chan<- prometheus.Metric) {
+	start := time.Now()
+	info, err := parser.FetchAndParse(context.Background(), c.device, c.metrics)
+	duration := time.Since(start).Seconds()
+
+	success := 1.0
+	if err != nil || info == nil || !info.Up {
+		success = 0.0
+	}
+	ch <- prometheus.MustNewConstMetric(c.duration, prometheus.GaugeValue, duration, c.device.URL)
+	ch <- prometheus.MustNewConstMetric(c.success, prometheus.GaugeValue, success, c.device.URL)
+
+	if err != nil || info == nil {
+		return
+	}
+
+	upValue := 0.0
+	if info.Up {
+		upValue = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, upValue, info.URL)
+
+	if !info.Up {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1,
+		info.URL, getOrDefault(info.Name, UnknownValue))
+	ch <- prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, 1,
+		info.URL, getOrDefault(info.State, UnknownValue))
+	ch <- prometheus.MustNewConstMetric(c.network, prometheus.GaugeValue, 1,
+		info.URL, getOrDefault(info.MAC, UnknownValue))
+	ch <- prometheus.MustNewConstMetric(c.firmware, prometheus.GaugeValue, 1,
+		info.URL, getOrDefault(info.Firmware, UnknownValue), getOrDefault(info.Name, UnknownValue))
+
+	if info.FreeMem > 0 {
+		ch <- prometheus.MustNewConstMetric(c.memory, prometheus.GaugeValue, info.FreeMem,
+			info.URL, getOrDefault(info.Name, UnknownValue))
+	}
+}
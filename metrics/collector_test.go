@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"supla_exporter/config"
+	"supla_exporter/parser"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestNewCollectorCoexistence verifies that two Collector instances can be
+// created in the same process without tripping "duplicate metrics
+// collector registration attempted", as long as each is bound to its own
+// Registerer. This is the scenario the injectable-registry refactor was
+// meant to make possible.
+func TestNewCollectorCoexistence(t *testing.T) {
+	reg1 := prometheus.NewRegistry()
+	reg2 := prometheus.NewRegistry()
+
+	c1 := NewCollector(reg1)
+	c2 := NewCollector(reg2)
+
+	c1.SetDeviceCount(1)
+	c2.SetDeviceCount(2)
+
+	if _, err := reg1.Gather(); err != nil {
+		t.Fatalf("gathering reg1: %v", err)
+	}
+	if _, err := reg2.Gather(); err != nil {
+		t.Fatalf("gathering reg2: %v", err)
+	}
+}
+
+// TestDeviceCollectorCollectSuccess verifies that a successful probe scrape
+// reports the device as up with its info/state/network series populated.
+func TestDeviceCollectorCollectSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"dev1","state":"NORMAL","mac":"AA:BB:CC:DD:EE:FF"}`))
+	}))
+	defer srv.Close()
+
+	device := config.Device{URL: srv.URL, Username: "u", Password: "p"}
+	m := parser.NewMetrics(prometheus.NewRegistry())
+	c := NewDeviceCollector(device, m)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gathering: %v", err)
+	}
+
+	var sawUp, sawInfo bool
+	for _, f := range families {
+		switch f.GetName() {
+		case "supla_device_up":
+			sawUp = true
+			if got := f.GetMetric()[0].GetGauge().GetValue(); got != 1 {
+				t.Fatalf("expected supla_device_up=1, got %v", got)
+			}
+		case "supla_device_info":
+			sawInfo = true
+		}
+	}
+	if !sawUp || !sawInfo {
+		t.Fatalf("expected supla_device_up and supla_device_info series, families: %v", families)
+	}
+}
+
+// TestDeviceCollectorCollectUnreachableDeviceDoesNotPanic verifies that
+// probing a device that can't be reached (e.g. an unknown /probe target)
+// reports it as down instead of panicking, and still emits duration/success.
+func TestDeviceCollectorCollectUnreachableDeviceDoesNotPanic(t *testing.T) {
+	device := config.Device{URL: "http://127.0.0.1:1/status"}
+	m := parser.NewMetrics(prometheus.NewRegistry())
+	c := NewDeviceCollector(device, m)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gathering: %v", err)
+	}
+
+	var sawSuccess bool
+	for _, f := range families {
+		if f.GetName() == "supla_scrape_success" {
+			sawSuccess = true
+			if got := f.GetMetric()[0].GetGauge().GetValue(); got != 0 {
+				t.Fatalf("expected supla_scrape_success=0, got %v", got)
+			}
+		}
+	}
+	if !sawSuccess {
+		t.Fatalf("expected a supla_scrape_success series, families: %v", families)
+	}
+}
+
+// TestNewCollectorRegistersBuildInfoAndRuntimeCollectors verifies that
+// SetBuildInfo surfaces supla_exporter_build_info with the given labels,
+// and that the Go runtime and process collectors NewCollector registers
+// alongside it actually land on reg.
+func TestNewCollectorRegistersBuildInfoAndRuntimeCollectors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c := NewCollector(reg)
+	c.SetBuildInfo("1.2.3", "abc123", "go1.22.0")
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gathering: %v", err)
+	}
+
+	var sawBuildInfo, sawGo, sawProcess bool
+	for _, f := range families {
+		switch {
+		case f.GetName() == "supla_exporter_build_info":
+			sawBuildInfo = true
+			labels := f.GetMetric()[0].GetLabel()
+			got := map[string]string{}
+			for _, l := range labels {
+				got[l.GetName()] = l.GetValue()
+			}
+			if got["version"] != "1.2.3" || got["commit"] != "abc123" || got["go_version"] != "go1.22.0" {
+				t.Fatalf("unexpected build_info labels: %+v", got)
+			}
+			if val := f.GetMetric()[0].GetGauge().GetValue(); val != 1 {
+				t.Fatalf("expected supla_exporter_build_info=1, got %v", val)
+			}
+		case strings.HasPrefix(f.GetName(), "go_"):
+			sawGo = true
+		case strings.HasPrefix(f.GetName(), "process_"):
+			sawProcess = true
+		}
+	}
+
+	if !sawBuildInfo {
+		t.Fatal("expected a supla_exporter_build_info series")
+	}
+	if !sawGo {
+		t.Fatal("expected go_* series from the registered Go runtime collector")
+	}
+	if !sawProcess {
+		t.Fatal("expected process_* series from the registered process collector")
+	}
+}
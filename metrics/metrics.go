@@ -5,7 +5,7 @@ import (
 	"supla_exporter/parser"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
 const (
@@ -13,71 +13,112 @@ const (
 	UnknownValue = "UNKNOWN"
 )
 
-var (
-	// Base device info - identity and static info
-	SuplaDeviceInfo = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "supla_device_info",
-			Help: "Base device information indicating device presence",
-		},
-		[]string{"url", "name"},
-	)
-
-	// Separate state metric
-	SuplaDeviceState = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "supla_device_state",
-			Help: "Device operational state",
-		},
-		[]string{"url", "state"},
-	)
+// Collector owns the exporter's device GaugeVecs and registers them on an
+// injected prometheus.Registerer instead of the package-level promauto
+// globals this used to be. That makes it possible to run several exporter
+// instances (or test fixtures) in the same process without tripping
+// "duplicate metrics collector registration attempted".
+type Collector struct {
+	deviceInfo     *prometheus.GaugeVec
+	deviceState    *prometheus.GaugeVec
+	deviceNetwork  *prometheus.GaugeVec
+	deviceFirmware *prometheus.GaugeVec
+	deviceMemory   *prometheus.GaugeVec
+	deviceUp       *prometheus.GaugeVec
+	deviceCount    prometheus.Gauge
+	buildInfo      *prometheus.GaugeVec
+}
 
-	// Network information
-	SuplaDeviceNetwork = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "supla_device_network",
-			Help: "Device network information",
-		},
-		[]string{"url", "mac"},
-	)
+// NewCollector builds the exporter's GaugeVecs and registers them, plus the
+// Go runtime and process collectors, on reg.
+func NewCollector(reg prometheus.Registerer) *Collector {
+	c := &Collector{
+		deviceInfo: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "supla_device_info",
+				Help: "Base device information indicating device presence",
+			},
+			[]string{"url", "name"},
+		),
+		deviceState: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "supla_device_state",
+				Help: "Device operational state",
+			},
+			[]string{"url", "state"},
+		),
+		deviceNetwork: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "supla_device_network",
+				Help: "Device network information",
+			},
+			[]string{"url", "mac"},
+		),
+		deviceFirmware: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "supla_device_firmware",
+				Help: "Device firmware version",
+			},
+			[]string{"url", "firmware", "name"},
+		),
+		deviceMemory: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "supla_device_memory_free_bytes",
+				Help: "Free memory in bytes",
+			},
+			[]string{"url", "name"},
+		),
+		deviceUp: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "supla_device_up",
+				Help: "Device availability status (1=up, 0=down)",
+			},
+			[]string{"url"},
+		),
+		deviceCount: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "supla_device_count",
+				Help: "Total number of Supla devices",
+			},
+		),
+		buildInfo: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "supla_exporter_build_info",
+				Help: "Build information about the running supla_exporter binary",
+			},
+			[]string{"version", "commit", "go_version"},
+		),
+	}
 
-	// Firmware information
-	SuplaDeviceFirmware = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "supla_device_firmware",
-			Help: "Device firmware version",
-		},
-		[]string{"url", "firmware", "name"},
+	reg.MustRegister(
+		c.deviceInfo,
+		c.deviceState,
+		c.deviceNetwork,
+		c.deviceFirmware,
+		c.deviceMemory,
+		c.deviceUp,
+		c.deviceCount,
+		c.buildInfo,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 	)
 
-	// Memory metric (renamed to be clearer)
-	SuplaDeviceMemory = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "supla_device_memory_free_bytes",
-			Help: "Free memory in bytes",
-		},
-		[]string{"url", "name"},
-	)
+	return c
+}
 
-	// Availability status (unchanged)
-	SuplaDeviceUp = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "supla_device_up",
-			Help: "Device availability status (1=up, 0=down)",
-		},
-		[]string{"url"},
-	)
+// SetBuildInfo records the running binary's version, commit, and Go
+// toolchain version on the build_info gauge.
+func (c *Collector) SetBuildInfo(version, commit, goVersion string) {
+	c.buildInfo.WithLabelValues(version, commit, goVersion).Set(1)
+}
 
-	// Total count (unchanged)
-	SuplaDeviceCount = promauto.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "supla_device_count",
-			Help: "Total number of Supla devices",
-		},
-	)
-)
+// SetDeviceCount records how many devices were scraped during a tick.
+func (c *Collector) SetDeviceCount(count int64) {
+	c.deviceCount.Set(float64(count))
+}
 
-func UpdateMetrics(info *parser.SuplaInfo) {
+// UpdateMetrics updates all per-device series from a fresh scrape result.
+func (c *Collector) UpdateMetrics(info *parser.SuplaInfo) {
 	// Convert bool to float64 (1.0 = up, 0.0 = down)
 	upValue := 0.0
 	if info.Up {
@@ -85,7 +126,7 @@ func UpdateMetrics(info *parser.SuplaInfo) {
 	}
 
 	// Update device up/down status metric (always emitted, never deleted)
-	SuplaDeviceUp.WithLabelValues(info.URL).Set(upValue)
+	c.deviceUp.WithLabelValues(info.URL).Set(upValue)
 
 	if upValue == 0 {
 		slog.Debug("Device not present: ", "url", info.URL)
@@ -93,32 +134,32 @@ func UpdateMetrics(info *parser.SuplaInfo) {
 	}
 
 	// Clean existing metrics for this device (only when we have fresh data to replace them)
-	SuplaDeviceInfo.DeletePartialMatch(prometheus.Labels{"url": info.URL})
-	SuplaDeviceState.DeletePartialMatch(prometheus.Labels{"url": info.URL})
-	SuplaDeviceNetwork.DeletePartialMatch(prometheus.Labels{"url": info.URL})
-	SuplaDeviceFirmware.DeletePartialMatch(prometheus.Labels{"url": info.URL})
-	SuplaDeviceMemory.DeletePartialMatch(prometheus.Labels{"url": info.URL})
+	c.deviceInfo.DeletePartialMatch(prometheus.Labels{"url": info.URL})
+	c.deviceState.DeletePartialMatch(prometheus.Labels{"url": info.URL})
+	c.deviceNetwork.DeletePartialMatch(prometheus.Labels{"url": info.URL})
+	c.deviceFirmware.DeletePartialMatch(prometheus.Labels{"url": info.URL})
+	c.deviceMemory.DeletePartialMatch(prometheus.Labels{"url": info.URL})
 
 	// Base device info
-	SuplaDeviceInfo.WithLabelValues(
+	c.deviceInfo.WithLabelValues(
 		info.URL,
 		getOrDefault(info.Name, UnknownValue),
 	).Set(1)
 
 	// Device state
-	SuplaDeviceState.WithLabelValues(
+	c.deviceState.WithLabelValues(
 		info.URL,
 		getOrDefault(info.State, UnknownValue),
 	).Set(1)
 
 	// Network info
-	SuplaDeviceNetwork.WithLabelValues(
+	c.deviceNetwork.WithLabelValues(
 		info.URL,
 		getOrDefault(info.MAC, UnknownValue),
 	).Set(1)
 
 	// Firmware info
-	SuplaDeviceFirmware.WithLabelValues(
+	c.deviceFirmware.WithLabelValues(
 		info.URL,
 		getOrDefault(info.Firmware, UnknownValue),
 		getOrDefault(info.Name, UnknownValue),
@@ -126,14 +167,23 @@ func UpdateMetrics(info *parser.SuplaInfo) {
 
 	// Memory (converted to bytes)
 	if info.FreeMem > 0 {
-		SuplaDeviceMemory.WithLabelValues(
+		c.deviceMemory.WithLabelValues(
 			info.URL,
 			getOrDefault(info.Name, UnknownValue),
 		).Set(info.FreeMem) // Converting KB to bytes
 	}
+}
 
-	// Update device count
-	SuplaDeviceCount.Set(float64(parser.GetDeviceCount()))
+// DeleteDevice removes all series for a device, used when it disappears
+// from the target set (e.g. removed from config.yaml or a discovery
+// provider) so stale series don't linger in /metrics forever.
+func (c *Collector) DeleteDevice(url string) {
+	c.deviceInfo.DeletePartialMatch(prometheus.Labels{"url": url})
+	c.deviceState.DeletePartialMatch(prometheus.Labels{"url": url})
+	c.deviceNetwork.DeletePartialMatch(prometheus.Labels{"url": url})
+	c.deviceFirmware.DeletePartialMatch(prometheus.Labels{"url": url})
+	c.deviceMemory.DeletePartialMatch(prometheus.Labels{"url": url})
+	c.deviceUp.DeleteLabelValues(url)
 }
 
 // getOrDefault returns the value if it's not empty, otherwise returns the default value
@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"supla_exporter/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestFetchAndParseReasonClassification verifies that FetchAndParse labels
+// supla_scrape_errors_total with the right reason for each class of
+// failure, and that supla_http_requests_total is incremented with the
+// response's status code whenever a response was actually received.
+func TestFetchAndParseReasonClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantReason string
+		wantCode   string // "" if no HTTP response is expected to be recorded
+	}{
+		{
+			name: "unauthorized",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			wantReason: "unauthorized",
+			wantCode:   "401",
+		},
+		{
+			name: "forbidden",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantReason: "forbidden",
+			wantCode:   "403",
+		},
+		{
+			name: "not_found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			wantReason: "not_found",
+			wantCode:   "404",
+		},
+		{
+			name: "http_5xx",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantReason: "http_5xx",
+			wantCode:   "500",
+		},
+		{
+			name: "parse_error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte("not json"))
+			},
+			wantReason: "parse_error",
+			wantCode:   "200",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			reg := prometheus.NewRegistry()
+			m := NewMetrics(reg)
+			device := config.Device{URL: srv.URL, Username: "u", Password: "p"}
+
+			info, err := FetchAndParse(context.Background(), device, m)
+			if err != nil {
+				t.Fatalf("FetchAndParse: %v", err)
+			}
+			if info.Up {
+				t.Fatalf("expected device to be reported down, got %+v", info)
+			}
+
+			errs := testutil.ToFloat64(m.ScrapeErrors.WithLabelValues(device.URL, tt.wantReason))
+			if errs != 1 {
+				t.Fatalf("expected 1 scrape error with reason %q, got %v", tt.wantReason, errs)
+			}
+
+			if tt.wantCode != "" {
+				reqs := testutil.ToFloat64(m.HTTPRequests.WithLabelValues(device.URL, tt.wantCode))
+				if reqs != 1 {
+					t.Fatalf("expected 1 HTTP request recorded with code %q, got %v", tt.wantCode, reqs)
+				}
+			}
+		})
+	}
+}
+
+// TestFetchAndParseTimeout verifies that a connection-level failure (no
+// response received at all) is classified as a timeout and never records
+// an HTTP request, since no response was received.
+func TestFetchAndParseTimeout(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewMetrics(reg)
+	device := config.Device{URL: "http://127.0.0.1:1/status"}
+
+	info, err := FetchAndParse(context.Background(), device, m)
+	if err != nil {
+		t.Fatalf("FetchAndParse: %v", err)
+	}
+	if info.Up {
+		t.Fatalf("expected device to be reported down, got %+v", info)
+	}
+
+	errs := testutil.ToFloat64(m.ScrapeErrors.WithLabelValues(device.URL, "timeout"))
+	if errs != 1 {
+		t.Fatalf("expected 1 scrape error with reason timeout, got %v", errs)
+	}
+
+	if reqs := testutil.CollectAndCount(m.HTTPRequests); reqs != 0 {
+		t.Fatalf("expected no HTTP requests recorded, got %d series", reqs)
+	}
+}
+
+// TestFetchAndParseSuccessRecordsHTTPRequest verifies the 200/ok path
+// increments supla_http_requests_total without recording a scrape error.
+func TestFetchAndParseSuccessRecordsHTTPRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"dev","up":true}`))
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	m := NewMetrics(reg)
+	device := config.Device{URL: srv.URL, Username: "u", Password: "p"}
+
+	info, err := FetchAndParse(context.Background(), device, m)
+	if err != nil {
+		t.Fatalf("FetchAndParse: %v", err)
+	}
+	if !info.Up {
+		t.Fatalf("expected device to be reported up, got %+v", info)
+	}
+
+	reqs := testutil.ToFloat64(m.HTTPRequests.WithLabelValues(device.URL, "200"))
+	if reqs != 1 {
+		t.Fatalf("expected 1 HTTP request recorded with code 200, got %v", reqs)
+	}
+
+	if errs := testutil.CollectAndCount(m.ScrapeErrors); errs != 0 {
+		t.Fatalf("expected no scrape errors recorded, got %d series", errs)
+	}
+
+	dur := testutil.CollectAndCount(m.ScrapeDuration)
+	if dur == 0 {
+		t.Fatal("expected supla_scrape_duration_seconds to have an observation")
+	}
+}
@@ -0,0 +1,26 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonParser decodes the structured JSON status exposed by some Supla
+// firmware builds directly into a SuplaInfo, instead of scraping the HTML
+// admin page.
+type jsonParser struct{}
+
+// CanParse implements Parser.
+func (jsonParser) CanParse(contentType string, _ []byte) bool {
+	return strings.Contains(strings.ToLower(contentType), "application/json")
+}
+
+// Parse implements Parser.
+func (jsonParser) Parse(body []byte) (*SuplaInfo, error) {
+	info := &SuplaInfo{}
+	if err := json.Unmarshal(body, info); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	}
+	return info, nil
+}
@@ -0,0 +1,64 @@
+package parser
+
+import "testing"
+
+func TestJSONParserCanParse(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/json", true},
+		{"application/json; charset=utf-8", true},
+		{"APPLICATION/JSON", true},
+		{"text/html", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := (jsonParser{}).CanParse(tt.contentType, nil); got != tt.want {
+			t.Errorf("CanParse(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestJSONParserParse(t *testing.T) {
+	body := []byte(`{"name":"dev1","state":"NORMAL","firmware":"v1.2.3","mac":"AA:BB:CC:DD:EE:FF","free_mem":12.5}`)
+
+	info, err := (jsonParser{}).Parse(body)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if info.Name != "dev1" || info.State != "NORMAL" || info.Firmware != "v1.2.3" || info.MAC != "AA:BB:CC:DD:EE:FF" || info.FreeMem != 12.5 {
+		t.Fatalf("unexpected SuplaInfo: %+v", info)
+	}
+}
+
+func TestJSONParserParseInvalidJSON(t *testing.T) {
+	if _, err := (jsonParser{}).Parse([]byte("not json")); err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+}
+
+// TestParseResponseContentNegotiation verifies parseResponse picks
+// jsonParser for a declared JSON Content-Type and falls back to
+// htmlParser (the catch-all) for everything else, matching the order
+// declared in the parsers chain.
+func TestParseResponseContentNegotiation(t *testing.T) {
+	jsonBody := []byte(`{"name":"dev1"}`)
+	info, err := parseResponse("application/json", jsonBody)
+	if err != nil {
+		t.Fatalf("parseResponse(json): %v", err)
+	}
+	if info.Name != "dev1" {
+		t.Fatalf("expected jsonParser to have been used, got %+v", info)
+	}
+
+	htmlBody := []byte(`<html><body><h1>dev2</h1></body></html>`)
+	info, err = parseResponse("text/html", htmlBody)
+	if err != nil {
+		t.Fatalf("parseResponse(html): %v", err)
+	}
+	if info.Name != "dev2" {
+		t.Fatalf("expected htmlParser to have been used, got %+v", info)
+	}
+}
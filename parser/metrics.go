@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the self-observability metrics for the scraping process
+// itself, following the mikrotik-exporter collector pattern: how long a
+// scrape took, whether it succeeded, why it failed, and what the device
+// returned over HTTP. Like metrics.Collector, it registers on an injected
+// prometheus.Registerer instead of promauto's implicit default registry,
+// so these series actually land on the registry the exporter serves.
+type Metrics struct {
+	ScrapeDuration *prometheus.HistogramVec
+	ScrapeSuccess  *prometheus.GaugeVec
+	ScrapeErrors   *prometheus.CounterVec
+	HTTPRequests   *prometheus.CounterVec
+
+	// DeviceScrapeRetries counts retries issued by Pool for transient
+	// failures (connection refused, 5xx, chunked-encoding).
+	DeviceScrapeRetries *prometheus.CounterVec
+}
+
+// NewMetrics builds the parser's self-observability metrics and registers
+// them on reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		ScrapeDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "supla_scrape_duration_seconds",
+				Help: "Time taken to scrape a device",
+			},
+			[]string{"url"},
+		),
+
+		ScrapeSuccess: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "supla_scrape_success",
+				Help: "Whether the last scrape of a device succeeded (1) or failed (0)",
+			},
+			[]string{"url"},
+		),
+
+		// ScrapeErrors reason values: tls_config, timeout, unauthorized,
+		// forbidden, not_found, http_5xx, chunked_encoding, parse_error.
+		ScrapeErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "supla_scrape_errors_total",
+				Help: "Total number of device scrape errors, by reason",
+			},
+			[]string{"url", "reason"},
+		),
+
+		HTTPRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "supla_http_requests_total",
+				Help: "Total number of HTTP requests made to devices, by response code",
+			},
+			[]string{"url", "code"},
+		),
+
+		DeviceScrapeRetries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "supla_device_scrape_retries_total",
+				Help: "Total number of device scrape retries, by device",
+			},
+			[]string{"url"},
+		),
+	}
+
+	reg.MustRegister(
+		m.ScrapeDuration,
+		m.ScrapeSuccess,
+		m.ScrapeErrors,
+		m.HTTPRequests,
+		m.DeviceScrapeRetries,
+	)
+
+	return m
+}
+
+// DeleteDevice removes all self-observability series for url, used
+// alongside metrics.Collector.DeleteDevice when a device disappears from
+// the target set so its scrape metrics don't linger in /metrics forever.
+func (m *Metrics) DeleteDevice(url string) {
+	m.ScrapeDuration.DeletePartialMatch(prometheus.Labels{"url": url})
+	m.ScrapeSuccess.DeletePartialMatch(prometheus.Labels{"url": url})
+	m.ScrapeErrors.DeletePartialMatch(prometheus.Labels{"url": url})
+	m.HTTPRequests.DeletePartialMatch(prometheus.Labels{"url": url})
+	m.DeviceScrapeRetries.DeletePartialMatch(prometheus.Labels{"url": url})
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log/slog"
@@ -8,7 +9,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"sync/atomic"
+	"time"
+
 	"supla_exporter/config"
 
 	"github.com/PuerkitoBio/goquery"
@@ -26,8 +28,6 @@ type SuplaInfo struct {
 	URL      string  `json:"url"`      // Device URL
 }
 
-var deviceCount int64
-
 // LogValue implements the slog.LogValuer interface
 func (si *SuplaInfo) LogValue() slog.Value {
 	return slog.GroupValue(
@@ -38,64 +38,43 @@ func (si *SuplaInfo) LogValue() slog.Value {
 	)
 }
 
-// FetchAndParseWithPool processes multiple devices using a worker pool
-func FetchAndParseWithPool(devices []config.Device, numWorkers int) []*SuplaInfo {
-
-	// Create channels for jobs and results
-	jobs := make(chan config.Device, len(devices))
-	results := make(chan *SuplaInfo, len(devices))
-
-	// Start workers
-	for w := 0; w < numWorkers; w++ {
-		go worker(jobs, results)
-	}
-
-	// Send jobs to workers
-	for _, device := range devices {
-		jobs <- device
-	}
-	close(jobs)
-
-	// Collect results
-	var infos []*SuplaInfo
-	for i := 0; i < len(devices); i++ {
-		info := <-results
-		infos = append(infos, info)
-	}
-
-	return infos
-}
-
-// worker processes jobs from jobs channel and sends results to results channel
-func worker(jobs <-chan config.Device, results chan<- *SuplaInfo) {
-	for device := range jobs {
-		info, err := FetchAndParse(device)
-		if err != nil {
-			// In case of error, send a basic error info
-			results <- &SuplaInfo{
-				URL:   device.URL,
-				Up:    false,
-				State: fmt.Sprintf("Error: %v", err),
-			}
-			continue
+// FetchAndParse gets content from URL with basic auth and parses it. ctx
+// bounds the request (and any chunked-encoding retry); callers that don't
+// need cancellation can pass context.Background(). m records the scrape's
+// self-observability metrics.
+func FetchAndParse(ctx context.Context, device config.Device, m *Metrics) (info *SuplaInfo, err error) {
+
+	// Time the whole call and record success/failure, so operators can see
+	// scrape health without relying solely on supla_device_up.
+	start := time.Now()
+	reason := ""
+	defer func() {
+		m.ScrapeDuration.WithLabelValues(device.URL).Observe(time.Since(start).Seconds())
+
+		success := 0.0
+		if info != nil && info.Up {
+			success = 1.0
 		}
-		results <- info
-	}
-}
-
-// FetchAndParse gets content from URL with basic auth and parses it
-func FetchAndParse(device config.Device) (*SuplaInfo, error) {
+		m.ScrapeSuccess.WithLabelValues(device.URL).Set(success)
 
-	// Increment device count (regardless of a status)
-	atomic.AddInt64(&deviceCount, 1)
+		if reason != "" {
+			m.ScrapeErrors.WithLabelValues(device.URL, reason).Inc()
+		}
+	}()
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: config.GetTimeout(),
+	// Create HTTP client with timeout and, for HTTPS devices, TLS settings
+	client, err := newHTTPClient(device)
+	if err != nil {
+		reason = "tls_config"
+		return &SuplaInfo{
+			URL:   device.URL,
+			Up:    false,
+			State: fmt.Sprintf("TLS configuration error: %v", err),
+		}, nil
 	}
 
 	// Create request
-	req, err := http.NewRequest("GET", device.URL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", device.URL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
@@ -103,16 +82,22 @@ func FetchAndParse(device config.Device) (*SuplaInfo, error) {
 	// Add basic auth
 	req.SetBasicAuth(device.Username, device.Password)
 
+	// Prefer structured JSON status if the firmware offers it, falling
+	// back to the HTML admin page otherwise.
+	req.Header.Set("Accept", "application/json, text/html;q=0.8, */*;q=0.5")
+
 	// Make request
 	resp, err := client.Do(req)
 	if err != nil {
 		// Connection/timeout error
+		reason = "timeout"
 		return &SuplaInfo{
 			URL: device.URL,
 			Up:  false,
 		}, nil
 	}
 	defer resp.Body.Close()
+	m.HTTPRequests.WithLabelValues(device.URL, strconv.Itoa(resp.StatusCode)).Inc()
 
 	// Check status code
 	switch resp.StatusCode {
@@ -120,6 +105,7 @@ func FetchAndParse(device config.Device) (*SuplaInfo, error) {
 		// All good, continue with parsing
 
 	case http.StatusUnauthorized: // 401
+		reason = "unauthorized"
 		return &SuplaInfo{
 			URL:   device.URL,
 			Up:    false,
@@ -127,6 +113,7 @@ func FetchAndParse(device config.Device) (*SuplaInfo, error) {
 		}, nil
 
 	case http.StatusForbidden: // 403
+		reason = "forbidden"
 		return &SuplaInfo{
 			URL:   device.URL,
 			Up:    false,
@@ -134,6 +121,7 @@ func FetchAndParse(device config.Device) (*SuplaInfo, error) {
 		}, nil
 
 	case http.StatusNotFound: // 404
+		reason = "not_found"
 		return &SuplaInfo{
 			URL:   device.URL,
 			Up:    false,
@@ -141,6 +129,7 @@ func FetchAndParse(device config.Device) (*SuplaInfo, error) {
 		}, nil
 
 	case http.StatusInternalServerError: // 500
+		reason = "http_5xx"
 		return &SuplaInfo{
 			URL:   device.URL,
 			Up:    false,
@@ -148,6 +137,9 @@ func FetchAndParse(device config.Device) (*SuplaInfo, error) {
 		}, nil
 
 	default:
+		if resp.StatusCode >= 500 {
+			reason = "http_5xx"
+		}
 		return &SuplaInfo{
 			Name:  device.URL,
 			URL:   device.URL,
@@ -160,6 +152,7 @@ func FetchAndParse(device config.Device) (*SuplaInfo, error) {
 	if err != nil {
 		// If it's a chunked encoding error, try to read the body differently
 		if strings.Contains(err.Error(), "malformed chunked encoding") {
+			reason = "chunked_encoding"
 			slog.Warn("Encountered malformed chunked encoding, attempting alternative read method",
 				"url", device.URL)
 
@@ -178,6 +171,7 @@ func FetchAndParse(device config.Device) (*SuplaInfo, error) {
 				}, nil
 			}
 			defer resp.Body.Close()
+			m.HTTPRequests.WithLabelValues(device.URL, strconv.Itoa(resp.StatusCode)).Inc()
 
 			// Try to read the body again
 			body, err = io.ReadAll(resp.Body)
@@ -190,6 +184,7 @@ func FetchAndParse(device config.Device) (*SuplaInfo, error) {
 				}, nil
 			}
 		} else {
+			reason = "timeout"
 			return &SuplaInfo{
 				URL:   device.URL,
 				Up:    false,
@@ -199,13 +194,15 @@ func FetchAndParse(device config.Device) (*SuplaInfo, error) {
 		}
 	}
 
-	// Parse HTML and set device as up
-	info, err := ParseHTML(string(body))
+	// Parse the response with whichever registered Parser claims it, and
+	// set device as up
+	info, err = parseResponse(resp.Header.Get("Content-Type"), body)
 	if err != nil {
+		reason = "parse_error"
 		return &SuplaInfo{
 			URL:   device.URL,
 			Up:    false,
-			State: fmt.Sprintf("Error parsing HTML: %v", err),
+			State: fmt.Sprintf("Error parsing response: %v", err),
 		}, nil
 	}
 	info.URL = device.URL
@@ -218,6 +215,22 @@ func FetchAndParse(device config.Device) (*SuplaInfo, error) {
 	return info, nil
 }
 
+// htmlParser scrapes the legacy Supla admin page. It is the catch-all
+// fallback in the parser chain: any response not claimed by an earlier
+// Parser (e.g. jsonParser) ends up here.
+type htmlParser struct{}
+
+// CanParse implements Parser. It matches everything, since it is the
+// fallback for firmware that doesn't offer a structured endpoint.
+func (htmlParser) CanParse(_ string, _ []byte) bool {
+	return true
+}
+
+// Parse implements Parser.
+func (htmlParser) Parse(body []byte) (*SuplaInfo, error) {
+	return ParseHTML(string(body))
+}
+
 func ParseHTML(content string) (*SuplaInfo, error) {
 
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
@@ -291,15 +304,3 @@ func ParseHTML(content string) (*SuplaInfo, error) {
 
 	return info, nil
 }
-
-// GetAndResetDeviceCount returns the current count of devices and resets it to 0
-func GetAndResetDeviceCount() int64 {
-	return atomic.SwapInt64(&deviceCount, 0)
-}
-
-// GetDeviceCount returns the current count of devices without resetting it
-func GetDeviceCount() int64 {
-	count := atomic.LoadInt64(&deviceCount)
-	slog.Debug("Device count retrieved", "device_count", count)
-	return count
-}
@@ -0,0 +1,33 @@
+package parser
+
+import "fmt"
+
+// Parser turns a device response body into a SuplaInfo. Implementations
+// are tried in order by parseResponse, so additional firmwares or
+// channel-state endpoints can be supported without touching FetchAndParse.
+type Parser interface {
+	// CanParse reports whether this Parser can handle a response with the
+	// given Content-Type header and body.
+	CanParse(contentType string, body []byte) bool
+	// Parse decodes body into a SuplaInfo.
+	Parse(body []byte) (*SuplaInfo, error)
+}
+
+// parsers is the chain tried, in order, by parseResponse. jsonParser is
+// checked first since it is unambiguous (a declared Content-Type), and
+// htmlParser is the catch-all fallback for the existing HTML admin page.
+var parsers = []Parser{
+	jsonParser{},
+	htmlParser{},
+}
+
+// parseResponse runs body through the first Parser in the chain that
+// claims contentType/body.
+func parseResponse(contentType string, body []byte) (*SuplaInfo, error) {
+	for _, p := range parsers {
+		if p.CanParse(contentType, body) {
+			return p.Parse(body)
+		}
+	}
+	return nil, fmt.Errorf("no parser available for content-type %q", contentType)
+}
@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"supla_exporter/config"
+)
+
+// retryAttempts is how many times in total a device is fetched when its
+// scrape keeps failing transiently (connection refused, 5xx,
+// chunked-encoding): the first try plus up to retryAttempts-1 retries.
+const retryAttempts = 3
+
+// retryBaseBackoff is the base of the exponential backoff between retries;
+// a random jitter up to the computed backoff is added on top.
+const retryBaseBackoff = 200 * time.Millisecond
+
+// Pool fans device scrapes out across a fixed number of worker goroutines
+// and tracks how many devices were scraped since the last reset. It
+// replaces the old FetchAndParseWithPool package function + package-level
+// deviceCount global, so a process can run more than one Pool (e.g. in
+// tests) without the instances stepping on each other.
+type Pool struct {
+	numWorkers  int
+	metrics     *Metrics
+	deviceCount int64
+}
+
+// NewPool builds a Pool that fans work out across numWorkers goroutines,
+// recording scrapes on m.
+func NewPool(numWorkers int, m *Metrics) *Pool {
+	return &Pool{numWorkers: numWorkers, metrics: m}
+}
+
+// Run scrapes devices across the pool's worker goroutines. Each scrape gets
+// its own timeout derived from ctx, a panic in a worker is recovered into a
+// normal error result, and transient failures are retried with exponential
+// backoff and jitter. If ctx is cancelled while results are still coming in
+// (e.g. on SIGINT/SIGTERM), Run stops waiting and returns whatever was
+// collected so far instead of blocking forever.
+func (p *Pool) Run(ctx context.Context, devices []config.Device) []*SuplaInfo {
+	jobs := make(chan config.Device, len(devices))
+	results := make(chan *SuplaInfo, len(devices))
+
+	for w := 0; w < p.numWorkers; w++ {
+		go p.worker(ctx, jobs, results)
+	}
+
+	for _, device := range devices {
+		jobs <- device
+	}
+	close(jobs)
+
+	infos := make([]*SuplaInfo, 0, len(devices))
+	for i := 0; i < len(devices); i++ {
+		select {
+		case info := <-results:
+			infos = append(infos, info)
+		case <-ctx.Done():
+			slog.Warn("Scrape cancelled before all devices were processed",
+				"processed", i, "total", len(devices))
+			return infos
+		}
+	}
+
+	return infos
+}
+
+func (p *Pool) worker(ctx context.Context, jobs <-chan config.Device, results chan<- *SuplaInfo) {
+	for device := range jobs {
+		results <- p.scrapeWithRetry(ctx, device)
+	}
+}
+
+// scrapeWithRetry fetches a single device, retrying transient failures with
+// backoff, and recovers any panic raised along the way into an error result
+// so one bad device can't take down a worker goroutine.
+func (p *Pool) scrapeWithRetry(ctx context.Context, device config.Device) (result *SuplaInfo) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Recovered from panic while scraping device", "url", device.URL, "panic", r)
+			result = &SuplaInfo{URL: device.URL, Up: false, State: fmt.Sprintf("Panic: %v", r)}
+		}
+	}()
+
+	atomic.AddInt64(&p.deviceCount, 1)
+
+	var info *SuplaInfo
+	var err error
+
+	for attempt := 0; attempt < retryAttempts; attempt++ {
+		reqCtx, cancel := context.WithTimeout(ctx, config.GetTimeout())
+		info, err = FetchAndParse(reqCtx, device, p.metrics)
+		cancel()
+
+		if err != nil || !isTransient(info) {
+			break
+		}
+		if attempt == retryAttempts-1 {
+			break
+		}
+
+		p.metrics.DeviceScrapeRetries.WithLabelValues(device.URL).Inc()
+
+		backoff := retryBaseBackoff * time.Duration(1<<attempt)
+		jitter := time.Duration(rand.Int63n(int64(backoff)))
+		select {
+		case <-time.After(backoff + jitter):
+		case <-ctx.Done():
+			return info
+		}
+	}
+
+	if err != nil {
+		return &SuplaInfo{URL: device.URL, Up: false, State: fmt.Sprintf("Error: %v", err)}
+	}
+	return info
+}
+
+// isTransient reports whether a failed scrape looks worth retrying:
+// connection errors (no State set), 5xx responses, or a chunked-encoding
+// read that failed even after FetchAndParse's own identity-encoding retry.
+func isTransient(info *SuplaInfo) bool {
+	if info == nil || info.Up {
+		return false
+	}
+	if info.State == "" || info.State == "Timeout" || info.State == "Internal server error" {
+		return true
+	}
+	return strings.Contains(info.State, "HTTP error 5")
+}
+
+// GetAndResetDeviceCount returns the current count of devices and resets it to 0
+func (p *Pool) GetAndResetDeviceCount() int64 {
+	return atomic.SwapInt64(&p.deviceCount, 0)
+}
+
+// GetDeviceCount returns the current count of devices without resetting it
+func (p *Pool) GetDeviceCount() int64 {
+	count := atomic.LoadInt64(&p.deviceCount)
+	slog.Debug("Device count retrieved", "device_count", count)
+	return count
+}
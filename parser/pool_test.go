@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"supla_exporter/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestNewMetricsCoexistence verifies that two Metrics instances (as used by
+// two Pools) can be registered in the same process as long as each is
+// bound to its own Registerer.
+func TestNewMetricsCoexistence(t *testing.T) {
+	NewMetrics(prometheus.NewRegistry())
+	NewMetrics(prometheus.NewRegistry())
+}
+
+// TestPoolRunRetriesTransientFailures verifies that Pool retries a device
+// that returns a transient 5xx error before eventually succeeding, and
+// records the retries on DeviceScrapeRetries.
+func TestPoolRunRetriesTransientFailures(t *testing.T) {
+	var requests int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"dev","up":true}`))
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	m := NewMetrics(reg)
+	pool := NewPool(1, m)
+
+	device := config.Device{URL: srv.URL, Username: "u", Password: "p"}
+	results := pool.Run(context.Background(), []config.Device{device})
+
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if !results[0].Up {
+		t.Fatalf("expected device to end up as up after retries, got %+v", results[0])
+	}
+	if got := atomic.LoadInt64(&requests); got != 3 {
+		t.Fatalf("expected 3 requests (2 failures + 1 success), got %d", got)
+	}
+
+	retries := testutil.ToFloat64(m.DeviceScrapeRetries.WithLabelValues(device.URL))
+	if retries != 2 {
+		t.Fatalf("expected 2 recorded retries, got %v", retries)
+	}
+}
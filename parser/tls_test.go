@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"supla_exporter/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNewHTTPClientPlainDevice(t *testing.T) {
+	client, err := newHTTPClient(config.Device{URL: "http://example.invalid"})
+	if err != nil {
+		t.Fatalf("newHTTPClient: %v", err)
+	}
+	if client.Transport != nil {
+		t.Fatalf("expected a plain client with no TLS transport, got %+v", client.Transport)
+	}
+}
+
+func TestNewHTTPClientInsecureSkipVerify(t *testing.T) {
+	client, err := newHTTPClient(config.Device{URL: "https://example.invalid", InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("newHTTPClient: %v", err)
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected *http.Transport, got %T", client.Transport)
+	}
+	if !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Fatal("expected InsecureSkipVerify to be set on the TLS config")
+	}
+}
+
+func TestNewHTTPClientUnreadableCACertFile(t *testing.T) {
+	_, err := newHTTPClient(config.Device{URL: "https://example.invalid", CACertFile: "/nonexistent/ca.pem"})
+	if err == nil {
+		t.Fatal("expected an error for an unreadable ca_cert_file")
+	}
+}
+
+// TestNewHTTPClientScrapesTLSServer verifies that a device configured with
+// insecure_skip_verify can scrape an HTTPS device presenting a self-signed
+// certificate, exercising the outgoing client TLS path end to end.
+func TestNewHTTPClientScrapesTLSServer(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"dev1"}`))
+	}))
+	defer srv.Close()
+
+	device := config.Device{URL: srv.URL, Username: "u", Password: "p", InsecureSkipVerify: true}
+	info, err := FetchAndParse(context.Background(), device, NewMetrics(prometheus.NewRegistry()))
+	if err != nil {
+		t.Fatalf("FetchAndParse: %v", err)
+	}
+	if !info.Up {
+		t.Fatalf("expected device to be up, got %+v", info)
+	}
+}
@@ -0,0 +1,97 @@
+package webconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+var tlsVersions = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"NoClientCert":               tls.NoClientCert,
+	"RequestClientCert":          tls.RequestClientCert,
+	"RequireAnyClientCert":       tls.RequireAnyClientCert,
+	"VerifyClientCertIfGiven":    tls.VerifyClientCertIfGiven,
+	"RequireAndVerifyClientCert": tls.RequireAndVerifyClientCert,
+}
+
+var cipherSuites = func() map[string]uint16 {
+	suites := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		suites[s.Name] = s.ID
+	}
+	for _, s := range tls.InsecureCipherSuites() {
+		suites[s.Name] = s.ID
+	}
+	return suites
+}()
+
+// TLSConfig builds a *tls.Config from the server's cert/key, optional
+// client CA (for mTLS), minimum TLS version, and cipher suites.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	s := c.TLSServerConfig
+
+	if s.CertFile == "" || s.KeyFile == "" {
+		return nil, fmt.Errorf("tls_server_config.cert_file and key_file are required")
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading server certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if s.MinVersion != "" {
+		version, ok := tlsVersions[s.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unknown tls_server_config.min_version: %s", s.MinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if len(s.CipherSuites) > 0 {
+		ids := make([]uint16, 0, len(s.CipherSuites))
+		for _, name := range s.CipherSuites {
+			id, ok := cipherSuites[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown tls_server_config.cipher_suites entry: %s", name)
+			}
+			ids = append(ids, id)
+		}
+		tlsConfig.CipherSuites = ids
+	}
+
+	if s.ClientCAs != "" {
+		caCert, err := os.ReadFile(s.ClientCAs)
+		if err != nil {
+			return nil, fmt.Errorf("reading client_ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no valid certificates found in %s", s.ClientCAs)
+		}
+		tlsConfig.ClientCAs = pool
+
+		clientAuth := tls.RequireAndVerifyClientCert
+		if s.ClientAuthType != "" {
+			var ok bool
+			clientAuth, ok = clientAuthTypes[s.ClientAuthType]
+			if !ok {
+				return nil, fmt.Errorf("unknown tls_server_config.client_auth_type: %s", s.ClientAuthType)
+			}
+		}
+		tlsConfig.ClientAuth = clientAuth
+	}
+
+	return tlsConfig, nil
+}
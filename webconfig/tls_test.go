@@ -0,0 +1,141 @@
+package webconfig
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// generateTestCert writes a minimal self-signed cert/key pair to dir and
+// returns their paths.
+func generateTestCert(t *testing.T, dir string) (certPath, keyPath string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "supla_exporter test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+
+	certPath = filepath.Join(dir, "cert.pem")
+	keyPath = filepath.Join(dir, "key.pem")
+
+	certOut, err := os.Create(certPath)
+	if err != nil {
+		t.Fatalf("creating cert file: %v", err)
+	}
+	defer certOut.Close()
+	if err := pem.Encode(certOut, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+		t.Fatalf("encoding cert: %v", err)
+	}
+
+	keyOut, err := os.Create(keyPath)
+	if err != nil {
+		t.Fatalf("creating key file: %v", err)
+	}
+	defer keyOut.Close()
+	if err := pem.Encode(keyOut, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}); err != nil {
+		t.Fatalf("encoding key: %v", err)
+	}
+
+	return certPath, keyPath
+}
+
+func TestTLSConfigLoadsCertAndMinVersion(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := generateTestCert(t, dir)
+
+	cfg := &Config{
+		TLSServerConfig: TLSServerConfig{
+			CertFile:   certPath,
+			KeyFile:    keyPath,
+			MinVersion: "TLS12",
+		},
+	}
+
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		t.Fatalf("TLSConfig: %v", err)
+	}
+	if len(tlsConfig.Certificates) != 1 {
+		t.Fatalf("expected 1 certificate, got %d", len(tlsConfig.Certificates))
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("expected MinVersion TLS12, got %x", tlsConfig.MinVersion)
+	}
+}
+
+func TestTLSConfigRequiresCertAndKey(t *testing.T) {
+	cfg := &Config{}
+	if _, err := cfg.TLSConfig(); err == nil {
+		t.Fatal("expected error when cert_file/key_file are missing")
+	}
+}
+
+func TestTLSConfigUnknownMinVersion(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := generateTestCert(t, dir)
+
+	cfg := &Config{
+		TLSServerConfig: TLSServerConfig{
+			CertFile:   certPath,
+			KeyFile:    keyPath,
+			MinVersion: "not-a-version",
+		},
+	}
+
+	if _, err := cfg.TLSConfig(); err == nil {
+		t.Fatal("expected error for unknown min_version")
+	}
+}
+
+func TestTLSConfigClientCAsEnablesMTLS(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := generateTestCert(t, dir)
+
+	caDir := filepath.Join(dir, "ca")
+	if err := os.MkdirAll(caDir, 0o755); err != nil {
+		t.Fatalf("creating ca dir: %v", err)
+	}
+	caPath, _ := generateTestCert(t, caDir)
+
+	cfg := &Config{
+		TLSServerConfig: TLSServerConfig{
+			CertFile:  certPath,
+			KeyFile:   keyPath,
+			ClientCAs: caPath,
+		},
+	}
+
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		t.Fatalf("TLSConfig: %v", err)
+	}
+	if tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("expected default client auth RequireAndVerifyClientCert, got %v", tlsConfig.ClientAuth)
+	}
+	if tlsConfig.ClientCAs == nil {
+		t.Fatal("expected ClientCAs pool to be set")
+	}
+}
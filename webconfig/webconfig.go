@@ -0,0 +1,82 @@
+// Package webconfig implements the subset of Prometheus's exporter-toolkit
+// web-config format this exporter needs: TLS (including mTLS) and
+// bcrypt-hashed basic auth for the metrics server.
+package webconfig
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// TLSServerConfig configures the metrics server's listening socket.
+type TLSServerConfig struct {
+	CertFile  string `yaml:"cert_file"`
+	KeyFile   string `yaml:"key_file"`
+	ClientCAs string `yaml:"client_ca_file"`
+	// ClientAuthType is one of Go's tls.ClientAuthType names, e.g.
+	// "RequireAndVerifyClientCert" to require mTLS.
+	ClientAuthType string   `yaml:"client_auth_type"`
+	MinVersion     string   `yaml:"min_version"`
+	CipherSuites   []string `yaml:"cipher_suites"`
+}
+
+// Config is the top-level web-config document.
+type Config struct {
+	TLSServerConfig TLSServerConfig `yaml:"tls_server_config"`
+	// BasicAuthUsers maps username to a bcrypt hash of their password.
+	BasicAuthUsers map[string]string `yaml:"basic_auth_users"`
+}
+
+// Load reads and parses a web-config file.
+func Load(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading web config file: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing web config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// BasicAuthMiddleware wraps next with HTTP basic auth, checked against
+// BasicAuthUsers. If no users are configured, requests pass through
+// unauthenticated.
+func (c *Config) BasicAuthMiddleware(next http.Handler) http.Handler {
+	if len(c.BasicAuthUsers) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok {
+			unauthorized(w)
+			return
+		}
+
+		hash, ok := c.BasicAuthUsers[user]
+		if !ok {
+			unauthorized(w)
+			return
+		}
+
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+			unauthorized(w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="supla_exporter"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
@@ -0,0 +1,112 @@
+package webconfig
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasicAuthMiddlewareNoUsersPassesThrough(t *testing.T) {
+	cfg := &Config{}
+
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	cfg.BasicAuthMiddleware(next).ServeHTTP(rec, req)
+
+	if !called {
+		t.Fatal("expected next handler to be called when no basic auth users are configured")
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+}
+
+func newBasicAuthConfig(t *testing.T, user, password string) *Config {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatalf("hashing password: %v", err)
+	}
+	return &Config{BasicAuthUsers: map[string]string{user: string(hash)}}
+}
+
+func TestBasicAuthMiddlewareMissingHeader(t *testing.T) {
+	cfg := newBasicAuthConfig(t, "admin", "secret")
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next handler should not be called without credentials")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	cfg.BasicAuthMiddleware(next).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestBasicAuthMiddlewareUnknownUser(t *testing.T) {
+	cfg := newBasicAuthConfig(t, "admin", "secret")
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next handler should not be called for an unknown user")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.SetBasicAuth("nobody", "secret")
+	rec := httptest.NewRecorder()
+	cfg.BasicAuthMiddleware(next).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestBasicAuthMiddlewareWrongPassword(t *testing.T) {
+	cfg := newBasicAuthConfig(t, "admin", "secret")
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next handler should not be called with a wrong password")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.SetBasicAuth("admin", "wrong")
+	rec := httptest.NewRecorder()
+	cfg.BasicAuthMiddleware(next).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestBasicAuthMiddlewareCorrectPassword(t *testing.T) {
+	cfg := newBasicAuthConfig(t, "admin", "secret")
+
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.SetBasicAuth("admin", "secret")
+	rec := httptest.NewRecorder()
+	cfg.BasicAuthMiddleware(next).ServeHTTP(rec, req)
+
+	if !called {
+		t.Fatal("expected next handler to be called with correct credentials")
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+}